@@ -0,0 +1,244 @@
+// Copyright (c) 2019, The GoKi Authors. All rights reserved.
+// Use of this source code is governed by a BSD-style
+// license that can be found in the LICENSE file.
+
+package gi3d
+
+import (
+	"image"
+	"image/draw"
+	"strings"
+
+	"github.com/goki/gi/gi"
+	"github.com/goki/gi/mat32"
+	"github.com/goki/gi/units"
+	"github.com/goki/ki/ki"
+	"github.com/goki/ki/kit"
+)
+
+// TextRun is one contiguously-styled run of text within an
+// AttributedText2D -- unlike Text2D's single HTML string, callers build
+// up a sequence of these directly, which is what Code3D uses to turn a
+// syntax-highlighter token stream into renderable text without going
+// through an HTML intermediate.
+type TextRun struct {
+	Text      string      `desc:"the run's text"`
+	Font      gi.FontStyle `desc:"font styling for this run"`
+	Color     gi.Color    `desc:"text color for this run"`
+	BgColor   gi.Color    `desc:"background color behind this run -- IsNil for transparent"`
+	Underline bool        `desc:"draw an underline beneath this run"`
+	Strike    bool        `desc:"draw a strikethrough through this run"`
+}
+
+// AttributedText2D presents a sequence of independently-styled text runs
+// on a vertically-oriented plane, the way Text2D presents a single HTML
+// string. It rasterizes all runs into one texture (sharing Text2D's
+// RenderText machinery for the underlying plane mesh and material), so
+// callers that need mixed colors/fonts/decorations within one label --
+// e.g. Code3D -- don't have to compose several Text2D nodes by hand.
+type AttributedText2D struct {
+	Object
+	Runs   []TextRun    `desc:"the styled runs to lay out left-to-right -- lines break only at newlines within a run's Text, with no wrapping at a maximum width"`
+	Sty    gi.Style     `json:"-" xml:"-" desc:"base styling settings -- individual runs override Font/Color/BgColor from this"`
+	TxtTex *TextureBase `view:"-" xml:"-" json:"-" desc:"texture object for the rendered runs"`
+}
+
+var KiT_AttributedText2D = kit.Types.AddType(&AttributedText2D{}, nil)
+
+// AddNewAttributedText2D adds a new AttributedText2D with the given runs
+// to the given parent.
+func AddNewAttributedText2D(sc *Scene, parent ki.Ki, name string, runs []TextRun) *AttributedText2D {
+	at := parent.AddNewChild(KiT_AttributedText2D, name).(*AttributedText2D)
+	tm := sc.Text2DPlaneMesh()
+	at.SetMesh(sc, tm)
+	at.Defaults()
+	at.Runs = runs
+	return at
+}
+
+func (at *AttributedText2D) Defaults() {
+	at.Object.Defaults()
+	at.Pose.Scale.SetScalar(.005)
+	at.SetProp("font-size", units.NewPt(18))
+	at.SetProp("margin", units.NewPx(2))
+	at.Mat.Bright = 5
+}
+
+func (at *AttributedText2D) Init3D(sc *Scene) {
+	at.RenderRuns(sc)
+	at.Node3DBase.Init3D(sc)
+}
+
+// runRender pairs one (possibly newline-split) piece of a TextRun with
+// its shaped gi.TextRender, so splitLines and RenderRuns can carry the
+// run's styling (color, decoration) alongside the laid-out text.
+type runRender struct {
+	run TextRun
+	tr  gi.TextRender
+}
+
+// splitLines breaks Runs into lines wherever a '\n' appears inside a
+// run's Text, so a run spanning multiple lines (as DefaultHighlighter's
+// TokenNone runs do) starts a new line at each newline instead of
+// being laid out as one long run. Each piece keeps the parent run's
+// styling.
+func splitLines(runs []TextRun) [][]TextRun {
+	var lines [][]TextRun
+	cur := []TextRun{}
+	for _, run := range runs {
+		parts := strings.Split(run.Text, "\n")
+		for i, part := range parts {
+			if i > 0 {
+				lines = append(lines, cur)
+				cur = []TextRun{}
+			}
+			if part == "" {
+				continue
+			}
+			piece := run
+			piece.Text = part
+			cur = append(cur, piece)
+		}
+	}
+	lines = append(lines, cur)
+	return lines
+}
+
+// RenderRuns lays out and rasterizes Runs into TxtTex. Call after
+// changing Runs. Runs are first split into lines at every '\n' (see
+// splitLines); each line is then laid out left-to-right and lines are
+// stacked top-to-bottom, so multi-line source (as Code3D displays) wraps
+// onto successive rows instead of overlapping at a single y.
+func (at *AttributedText2D) RenderRuns(sc *Scene) {
+	at.Sty.Defaults()
+	at.Sty.SetStyleProps(nil, *at.Properties(), sc.Viewport)
+	at.Sty.SetUnitContext(sc.Viewport, gi.Vec2DZero)
+
+	lines := splitLines(at.Runs)
+
+	var lineRenders [][]runRender
+	var width float32
+	var lineHeights []float32
+	for _, line := range lines {
+		var rrs []runRender
+		var lw, lh float32
+		for _, run := range line {
+			fs := run.Font
+			if !run.Color.IsNil() {
+				fs.Color = run.Color
+			}
+			ts := at.Sty.Text
+			var tr gi.TextRender
+			tr.SetHTML(run.Text, &fs, &ts, &at.Sty.UnContext, nil)
+			sz := tr.Size
+			tr.LayoutStdLR(&ts, &fs, &at.Sty.UnContext, sz)
+			rrs = append(rrs, runRender{run: run, tr: tr})
+			lw += tr.Size.X
+			if tr.Size.Y > lh {
+				lh = tr.Size.Y
+			}
+		}
+		if lh == 0 { // blank line -- still takes up a line's worth of height
+			var fs gi.FontStyle
+			fs.Defaults()
+			lh = fs.Face.Metrics.Height
+		}
+		lineRenders = append(lineRenders, rrs)
+		lineHeights = append(lineHeights, lh)
+		if lw > width {
+			width = lw
+		}
+	}
+
+	var height float32
+	for _, lh := range lineHeights {
+		height += lh
+	}
+
+	marg := at.Sty.Layout.Margin.Dots
+	width += 2 * marg
+	height += 2 * marg
+	szpt := image.Point{int(width + .5), int(height + .5)}
+	bounds := image.Rectangle{Max: szpt}
+
+	var img *image.RGBA
+	if at.TxtTex == nil {
+		at.TxtTex = &TextureBase{Nm: at.Nm}
+		tx := at.TxtTex.NewTex()
+		img = image.NewRGBA(bounds)
+		tx.SetImage(img)
+	} else {
+		img = at.TxtTex.Tex.Image().(*image.RGBA)
+	}
+	at.TxtTex.Tex.SetSize(szpt)
+
+	rs := &sc.RenderState
+	rs.Init(szpt.X, szpt.Y, img)
+	rs.PushBounds(bounds)
+	draw.Draw(rs.Image, bounds, &image.Uniform{at.Sty.Font.BgColor.Color}, image.ZP, draw.Src)
+
+	y := marg
+	for li, rrs := range lineRenders {
+		pos := gi.Vec2D{marg, y}
+		for _, rr := range rrs {
+			tr := rr.tr
+			run := rr.run
+			if !run.BgColor.IsNil() {
+				runBounds := image.Rectangle{
+					Min: image.Point{int(pos.X), int(pos.Y)},
+					Max: image.Point{int(pos.X + tr.Size.X), int(pos.Y + tr.Size.Y)},
+				}
+				draw.Draw(rs.Image, runBounds, &image.Uniform{run.BgColor}, image.ZP, draw.Src)
+			}
+			tr.Render(rs, pos)
+			if run.Underline || run.Strike {
+				at.decorate(rs, pos, tr.Size, run)
+			}
+			pos.X += tr.Size.X
+		}
+		y += lineHeights[li]
+	}
+	rs.PopBounds()
+	rs.Image = nil
+	at.Mat.SetTexture(sc, at.TxtTex)
+}
+
+// decorate draws an underline and/or strikethrough rule for a single run,
+// using the run's own color.
+func (at *AttributedText2D) decorate(rs *gi.RenderState, pos, sz gi.Vec2D, run TextRun) {
+	if run.Underline {
+		y := pos.Y + sz.Y - 1
+		drawRule(rs, pos.X, y, sz.X, run.Color)
+	}
+	if run.Strike {
+		y := pos.Y + sz.Y*0.5
+		drawRule(rs, pos.X, y, sz.X, run.Color)
+	}
+}
+
+// drawRule draws a 1px-tall horizontal rule -- shared by underline and
+// strikethrough decoration.
+func drawRule(rs *gi.RenderState, x, y, w float32, clr gi.Color) {
+	r := image.Rectangle{
+		Min: image.Point{int(x), int(y)},
+		Max: image.Point{int(x + w), int(y) + 1},
+	}
+	draw.Draw(rs.Image, r, &image.Uniform{clr}, image.ZP, draw.Src)
+}
+
+func (at *AttributedText2D) UpdateWorldMatrix(parWorld *mat32.Mat4) {
+	if at.TxtTex != nil {
+		at.Pose.Defaults()
+		tsz := at.TxtTex.Tex.Size()
+		szsc := mat32.Vec3{float32(tsz.X), float32(tsz.Y), 1}.Mul(at.Pose.Scale)
+		at.Pose.Matrix.SetTransform(at.Pose.Pos, at.Pose.Quat, szsc)
+	} else {
+		at.Pose.UpdateMatrix()
+	}
+	at.Pose.UpdateWorldMatrix(parWorld)
+	at.SetFlag(int(WorldMatrixUpdated))
+}
+
+func (at *AttributedText2D) RenderClass() RenderClasses {
+	return RClassTransTexture
+}
@@ -0,0 +1,235 @@
+// Copyright (c) 2019, The GoKi Authors. All rights reserved.
+// Use of this source code is governed by a BSD-style
+// license that can be found in the LICENSE file.
+
+package gi3d
+
+import (
+	"github.com/goki/gi/gi"
+	"github.com/goki/ki/ki"
+	"github.com/goki/ki/kit"
+)
+
+// TokenClass categorizes a lexed token for the purposes of syntax
+// coloring -- ColorTheme maps each class to a color. Kept deliberately
+// small (relative to a full language-server token set) since it only
+// needs to drive color choice, not semantic analysis.
+type TokenClass int
+
+const (
+	TokenNone TokenClass = iota
+	TokenKeyword
+	TokenString
+	TokenComment
+	TokenNumber
+	TokenType
+	TokenClassN
+)
+
+//go:generate stringer -type=TokenClass
+
+// Token is one lexed token of source code, as produced by a
+// SyntaxHighlighter.
+type Token struct {
+	Text  string     `desc:"the token's literal text"`
+	Class TokenClass `desc:"syntax class used to look up a color in the active ColorTheme"`
+}
+
+// SyntaxHighlighter tokenizes source code for a given language so it can
+// be turned into colored TextRun runs. Implementations wrap a lexer --
+// the default implementation wraps a lightweight embedded lexer, but
+// callers can plug in e.g. a chroma-backed highlighter for broader
+// language coverage.
+type SyntaxHighlighter interface {
+	// Lex tokenizes src (written in the named language) into a flat
+	// sequence of Tokens, including whitespace and newlines as TokenNone
+	// tokens so the caller can reconstruct line breaks.
+	Lex(lang string, src string) []Token
+}
+
+// ColorTheme maps syntax token classes to colors, for use by Code3D (or
+// any other caller turning a Token stream into styled TextRun runs).
+type ColorTheme struct {
+	Name    string              `desc:"name of this theme, for display/selection purposes"`
+	Default gi.Color            `desc:"fallback color for TokenNone and any class not otherwise listed"`
+	Colors  map[TokenClass]gi.Color `desc:"per-class color overrides"`
+}
+
+// Color returns the color for cls, falling back to Default if cls has no
+// explicit entry.
+func (ct *ColorTheme) Color(cls TokenClass) gi.Color {
+	if c, ok := ct.Colors[cls]; ok {
+		return c
+	}
+	return ct.Default
+}
+
+// DefaultColorTheme is a basic dark-background theme used by Code3D when
+// no theme is otherwise specified.
+var DefaultColorTheme = ColorTheme{
+	Name:    "gi3d-dark",
+	Default: gi.Color{221, 221, 221, 255},
+	Colors: map[TokenClass]gi.Color{
+		TokenKeyword: {198, 120, 221, 255},
+		TokenString:  {152, 195, 121, 255},
+		TokenComment: {92, 99, 112, 255},
+		TokenNumber:  {209, 154, 102, 255},
+		TokenType:    {229, 192, 123, 255},
+	},
+}
+
+// Code3D is a convenience node built on AttributedText2D that displays a
+// block of source code, tokenized via a pluggable SyntaxHighlighter and
+// colored via a ColorTheme, for in-scene code walkthroughs, log viewers,
+// and annotated 3D diagrams. Callers that would otherwise reimplement
+// styled text per-project can use this directly.
+type Code3D struct {
+	AttributedText2D
+	Source      string            `desc:"the source code to display"`
+	Lang        string            `desc:"language identifier passed to Highlighter.Lex, e.g. \"go\", \"python\""`
+	Highlighter SyntaxHighlighter `view:"-" desc:"tokenizer used to turn Source into colored runs -- defaults to DefaultHighlighter"`
+	Theme       ColorTheme        `desc:"token-class to color mapping -- defaults to DefaultColorTheme"`
+}
+
+var KiT_Code3D = kit.Types.AddType(&Code3D{}, nil)
+
+// AddNewCode3D adds a new Code3D node displaying source (in the named
+// language) to parent.
+func AddNewCode3D(sc *Scene, parent ki.Ki, name string, lang string, source string) *Code3D {
+	cd := parent.AddNewChild(KiT_Code3D, name).(*Code3D)
+	tm := sc.Text2DPlaneMesh()
+	cd.SetMesh(sc, tm)
+	cd.Defaults()
+	cd.Lang = lang
+	cd.Source = source
+	return cd
+}
+
+func (cd *Code3D) Defaults() {
+	cd.AttributedText2D.Defaults()
+	cd.Highlighter = DefaultHighlighter{}
+	cd.Theme = DefaultColorTheme
+}
+
+func (cd *Code3D) Init3D(sc *Scene) {
+	cd.BuildRuns()
+	cd.AttributedText2D.Init3D(sc)
+}
+
+// BuildRuns tokenizes Source via Highlighter and converts the resulting
+// Tokens into Runs using Theme, ready for AttributedText2D.RenderRuns.
+func (cd *Code3D) BuildRuns() {
+	if cd.Highlighter == nil {
+		cd.Highlighter = DefaultHighlighter{}
+	}
+	toks := cd.Highlighter.Lex(cd.Lang, cd.Source)
+	runs := make([]TextRun, 0, len(toks))
+	for _, tok := range toks {
+		runs = append(runs, TextRun{
+			Text:  tok.Text,
+			Font:  cd.Sty.Font,
+			Color: cd.Theme.Color(tok.Class),
+		})
+	}
+	cd.Runs = runs
+}
+
+// DefaultHighlighter is a minimal SyntaxHighlighter good enough to color
+// comments, strings, and numbers in most C-like and Python-like
+// languages without pulling in an external lexer. Callers that need
+// accurate, language-specific tokenization (e.g. wrapping chroma) should
+// set Code3D.Highlighter to their own implementation instead.
+type DefaultHighlighter struct{}
+
+var defaultKeywords = map[string]bool{
+	"func": true, "package": true, "import": true, "return": true,
+	"if": true, "else": true, "for": true, "range": true, "var": true,
+	"const": true, "type": true, "struct": true, "interface": true,
+	"def": true, "class": true, "from": true,
+	"while": true, "break": true, "continue": true, "nil": true, "true": true, "false": true,
+}
+
+func (DefaultHighlighter) Lex(lang string, src string) []Token {
+	var toks []Token
+	runes := []rune(src)
+	n := len(runes)
+	i := 0
+	flush := func(text string, cls TokenClass) {
+		if text != "" {
+			toks = append(toks, Token{Text: text, Class: cls})
+		}
+	}
+	for i < n {
+		r := runes[i]
+		switch {
+		case r == '/' && i+1 < n && runes[i+1] == '/':
+			j := i
+			for j < n && runes[j] != '\n' {
+				j++
+			}
+			flush(string(runes[i:j]), TokenComment)
+			i = j
+		case r == '#':
+			j := i
+			for j < n && runes[j] != '\n' {
+				j++
+			}
+			flush(string(runes[i:j]), TokenComment)
+			i = j
+		case r == '"' || r == '\'':
+			quote := r
+			j := i + 1
+			for j < n && runes[j] != quote {
+				j++
+			}
+			if j < n {
+				j++
+			}
+			flush(string(runes[i:j]), TokenString)
+			i = j
+		case r >= '0' && r <= '9':
+			j := i
+			for j < n && (runes[j] >= '0' && runes[j] <= '9' || runes[j] == '.') {
+				j++
+			}
+			flush(string(runes[i:j]), TokenNumber)
+			i = j
+		case isIdentRune(r):
+			j := i
+			for j < n && isIdentContRune(runes[j]) {
+				j++
+			}
+			word := string(runes[i:j])
+			cls := TokenNone
+			if defaultKeywords[word] {
+				cls = TokenKeyword
+			}
+			flush(word, cls)
+			i = j
+		default:
+			j := i
+			for j < n && !isIdentRune(runes[j]) && runes[j] != '"' && runes[j] != '\'' && runes[j] != '/' && runes[j] != '#' && !(runes[j] >= '0' && runes[j] <= '9') {
+				j++
+			}
+			if j == i {
+				j++
+			}
+			flush(string(runes[i:j]), TokenNone)
+			i = j
+		}
+	}
+	return toks
+}
+
+func isIdentRune(r rune) bool {
+	return r == '_' || (r >= 'a' && r <= 'z') || (r >= 'A' && r <= 'Z')
+}
+
+// isIdentContRune reports whether r can continue an identifier after its
+// first rune -- isIdentRune plus digits, so identifiers like utf8, i2, or
+// buf1 aren't split the moment a digit appears. Digits still can't start
+// an identifier: the lexer's digit case above already claims anything
+// beginning with '0'-'9' as a number before isIdentRune is ever checked.
+func isIdentContRune(r rune) bool {
+	return isIdentRune(r) || (r >= '0' && r <= '9')
+}
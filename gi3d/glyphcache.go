@@ -0,0 +1,308 @@
+// Copyright (c) 2019, The GoKi Authors. All rights reserved.
+// Use of this source code is governed by a BSD-style
+// license that can be found in the LICENSE file.
+
+package gi3d
+
+import (
+	"fmt"
+	"image"
+
+	"github.com/goki/gi/gi"
+	"github.com/goki/gi/mat32"
+)
+
+// GlyphAtlasSize is the default width and height (in pixels) of a single
+// glyph atlas texture page. Pages are square so the shelf packer below
+// can grow shelves across the full width before starting a new one.
+const GlyphAtlasSize = 2048
+
+// GlyphKey uniquely identifies one rasterized glyph variant in the
+// GlyphCache -- the same rune at the same font/size/subpixel offset
+// always maps to the same atlas slot, so Text2D / TextRun3D nodes that
+// share a font share the underlying texture and draw in one batch.
+type GlyphKey struct {
+	Face    string  `desc:"font face name (family + style) the glyph was rasterized with"`
+	Size    int     `desc:"font size in pixels the glyph was rasterized at"`
+	Rune    rune    `desc:"the glyph's rune"`
+	SubPixX float32 `desc:"fractional pixel x offset the glyph was rasterized at, quantized to a few bins -- improves hinting at small sizes"`
+}
+
+// GlyphRecord holds the GPU-relevant data the cache keeps for one glyph:
+// where it lives in the atlas, and the metrics needed to place a quad for
+// it relative to the pen position.
+type GlyphRecord struct {
+	UVMin   mat32.Vec2 `desc:"upper-left UV coordinate of this glyph's region in the atlas texture"`
+	UVMax   mat32.Vec2 `desc:"lower-right UV coordinate of this glyph's region in the atlas texture"`
+	Size    image.Point `desc:"glyph bitmap size in pixels"`
+	Bearing image.Point `desc:"offset from the pen position to the upper-left of the glyph bitmap"`
+	Advance float32     `desc:"horizontal distance to advance the pen after this glyph"`
+	epoch   uint64      // frame epoch this glyph was last referenced on -- protects it from eviction this frame
+}
+
+// shelf is one horizontal strip of the atlas packer -- glyphs are packed
+// left-to-right within a shelf, and shelves are stacked top-to-bottom.
+// This is a simple, fast approximation of a full skyline packer that
+// works well for glyphs because row heights within a font size cluster
+// tightly.
+type shelf struct {
+	y, h, nextX int
+}
+
+// GlyphCache is a scene-level cache of rasterized glyphs packed into a
+// shared atlas texture, modeled on the glyph rasterizer + texture cache
+// used by browser engines such as webrender. Text2D and TextRun3D look up
+// glyphs here instead of rasterizing a private texture per node, so many
+// text nodes sharing a font can be rendered with one bound texture and,
+// combined with InstancedMesh, a single batched draw call.
+type GlyphCache struct {
+	Tex        *TextureBase           `desc:"the shared atlas texture -- a single large RGBA page; no further pages are added when it fills"`
+	Glyphs     map[GlyphKey]*GlyphRecord `desc:"rasterized glyphs currently resident in the atlas, keyed by face/size/rune/subpixel"`
+	Epoch      uint64                 `desc:"current frame epoch -- incremented once per frame via StartFrame -- glyphs referenced via Glyph this epoch are protected from eviction"`
+	shelves    []shelf
+	dirtyMinY  int
+	dirtyMaxY  int
+	dirty      bool
+	atlasImg   *image.RGBA
+}
+
+// NewGlyphCache creates a glyph cache with a single atlas page of
+// GlyphAtlasSize x GlyphAtlasSize, ready to be installed on a Scene.
+func NewGlyphCache() *GlyphCache {
+	gc := &GlyphCache{
+		Glyphs: make(map[GlyphKey]*GlyphRecord),
+	}
+	gc.atlasImg = image.NewRGBA(image.Rect(0, 0, GlyphAtlasSize, GlyphAtlasSize))
+	gc.Tex = &TextureBase{Nm: "gi3d-glyph-atlas"}
+	tx := gc.Tex.NewTex()
+	tx.SetImage(gc.atlasImg)
+	return gc
+}
+
+// StartFrame advances the frame epoch -- call once per frame before
+// rendering any text that uses this cache, so Glyph can tell which
+// glyphs are still in use and protect them from eviction.
+func (gc *GlyphCache) StartFrame() {
+	gc.Epoch++
+}
+
+// Glyph returns the GlyphRecord for key, rasterizing it via rasterize and
+// packing it into the atlas if it is not already resident. rasterize is
+// called only when key is not resident (so again after the glyph has been
+// evicted, or after a failed pack) and must return an RGBA bitmap plus
+// the bearing (pen-to-upper-left offset) and advance for the glyph.
+func (gc *GlyphCache) Glyph(key GlyphKey, rasterize func() (img *image.RGBA, bearing image.Point, advance float32)) (*GlyphRecord, error) {
+	if gr, ok := gc.Glyphs[key]; ok {
+		gr.epoch = gc.Epoch
+		return gr, nil
+	}
+	img, bearing, advance := rasterize()
+	b := img.Bounds()
+	x, y, err := gc.pack(b.Dx(), b.Dy())
+	if err != nil {
+		if gc.evictOne() {
+			x, y, err = gc.pack(b.Dx(), b.Dy())
+		}
+		if err != nil {
+			return nil, err
+		}
+	}
+	gc.blit(img, x, y)
+	atlasSz := float32(GlyphAtlasSize)
+	gr := &GlyphRecord{
+		UVMin:   mat32.Vec2{float32(x) / atlasSz, float32(y) / atlasSz},
+		UVMax:   mat32.Vec2{float32(x+b.Dx()) / atlasSz, float32(y+b.Dy()) / atlasSz},
+		Size:    image.Point{b.Dx(), b.Dy()},
+		Bearing: bearing,
+		Advance: advance,
+		epoch:   gc.Epoch,
+	}
+	gc.Glyphs[key] = gr
+	return gr, nil
+}
+
+// pack finds room for a w x h glyph using the shelf packer, adding a new
+// shelf if no existing shelf has enough height and width remaining.
+func (gc *GlyphCache) pack(w, h int) (x, y int, err error) {
+	for i := range gc.shelves {
+		sh := &gc.shelves[i]
+		if sh.h >= h && sh.nextX+w <= GlyphAtlasSize {
+			x, y = sh.nextX, sh.y
+			sh.nextX += w
+			return x, y, nil
+		}
+	}
+	nextY := 0
+	if n := len(gc.shelves); n > 0 {
+		last := gc.shelves[n-1]
+		nextY = last.y + last.h
+	}
+	if nextY+h > GlyphAtlasSize {
+		return 0, 0, fmt.Errorf("gi3d.GlyphCache: atlas full, no room for a %dx%d glyph", w, h)
+	}
+	gc.shelves = append(gc.shelves, shelf{y: nextY, h: h, nextX: w})
+	return 0, nextY, nil
+}
+
+// evictOne removes the least-recently-used glyph not referenced in the
+// current frame epoch, then repacks every remaining glyph into a fresh
+// set of shelves so the space it held is actually reclaimed -- shelves
+// only ever grow their nextX cursor, so deleting a glyph's map entry
+// alone leaves a hole pack can never see again. Returns false (and
+// evicts/repacks nothing) if every resident glyph was referenced this
+// frame.
+func (gc *GlyphCache) evictOne() bool {
+	var oldestKey GlyphKey
+	oldestEpoch := gc.Epoch + 1
+	found := false
+	for k, gr := range gc.Glyphs {
+		if gr.epoch == gc.Epoch {
+			continue // protected: referenced this frame
+		}
+		if gr.epoch < oldestEpoch {
+			oldestEpoch = gr.epoch
+			oldestKey = k
+			found = true
+		}
+	}
+	if !found {
+		return false
+	}
+	delete(gc.Glyphs, oldestKey)
+	gc.repack()
+	return true
+}
+
+// repack rebuilds the shelf packer and atlas image from scratch, tightly
+// re-blitting every still-resident glyph from the top -- the only way to
+// actually reclaim the space evictOne's deleted glyph held, since shelf
+// nextX cursors never move backward.
+func (gc *GlyphCache) repack() {
+	old := gc.atlasImg
+	atlasSz := float32(GlyphAtlasSize)
+	gc.atlasImg = image.NewRGBA(image.Rect(0, 0, GlyphAtlasSize, GlyphAtlasSize))
+	gc.shelves = nil
+
+	for k, gr := range gc.Glyphs {
+		w := gr.Size.X
+		h := gr.Size.Y
+		oldX := int(gr.UVMin.X*atlasSz + 0.5)
+		oldY := int(gr.UVMin.Y*atlasSz + 0.5)
+		sub := old.SubImage(image.Rect(oldX, oldY, oldX+w, oldY+h)).(*image.RGBA)
+		x, y, err := gc.pack(w, h)
+		if err != nil {
+			// Shouldn't happen -- we're repacking the same (or fewer)
+			// glyphs that already fit in this atlas -- but if it ever
+			// does, drop the glyph rather than fail the whole repack;
+			// the next lookup just re-rasterizes it.
+			delete(gc.Glyphs, k)
+			continue
+		}
+		gc.blit(sub, x, y)
+		gr.UVMin = mat32.Vec2{float32(x) / atlasSz, float32(y) / atlasSz}
+		gr.UVMax = mat32.Vec2{float32(x+w) / atlasSz, float32(y+h) / atlasSz}
+	}
+	// the atlas layout changed entirely -- force a full re-upload rather
+	// than trust the dirty-rows bookkeeping blit just accumulated.
+	gc.dirtyMinY, gc.dirtyMaxY = 0, GlyphAtlasSize
+	gc.dirty = true
+}
+
+// blit copies img into the atlas at (x,y) and marks the affected rows
+// dirty so the next TransferSubImage call only re-uploads what changed.
+func (gc *GlyphCache) blit(img *image.RGBA, x, y int) {
+	b := img.Bounds()
+	for iy := 0; iy < b.Dy(); iy++ {
+		for ix := 0; ix < b.Dx(); ix++ {
+			gc.atlasImg.Set(x+ix, y+iy, img.At(b.Min.X+ix, b.Min.Y+iy))
+		}
+	}
+	if !gc.dirty {
+		gc.dirtyMinY, gc.dirtyMaxY = y, y+b.Dy()
+		gc.dirty = true
+	} else {
+		if y < gc.dirtyMinY {
+			gc.dirtyMinY = y
+		}
+		if y+b.Dy() > gc.dirtyMaxY {
+			gc.dirtyMaxY = y + b.Dy()
+		}
+	}
+}
+
+// TransferDirty uploads only the atlas rows touched since the last
+// TransferDirty call, via TextureBase.TransferSubImage, instead of
+// re-transferring the whole atlas texture on every new glyph.
+func (gc *GlyphCache) TransferDirty() {
+	if !gc.dirty {
+		return
+	}
+	rect := image.Rect(0, gc.dirtyMinY, GlyphAtlasSize, gc.dirtyMaxY)
+	gc.Tex.TransferSubImage(gc.atlasImg, rect)
+	gc.dirty = false
+}
+
+// GlyphQuad is one textured quad's worth of mesh data for a single glyph,
+// in the local coordinate frame of the TextRun3D or Text2D that emitted
+// it (pen-relative, in world/layout units, not pixels).
+type GlyphQuad struct {
+	Pos   mat32.Vec3 `desc:"upper-left position of the glyph quad relative to the run's origin, in world units"`
+	Size  mat32.Vec2 `desc:"width and height of the glyph quad, in world units"`
+	UVMin mat32.Vec2 `desc:"upper-left UV into the shared glyph atlas"`
+	UVMax mat32.Vec2 `desc:"lower-right UV into the shared glyph atlas"`
+}
+
+// TextRun3D is a lower-level building block than Text2D: it shapes a
+// string once against a GlyphCache and holds the resulting per-glyph
+// quads, without owning a private texture. Multiple TextRun3D nodes that
+// share a GlyphCache and font can be merged into a single InstancedMesh
+// batch by the caller (e.g. one quad-mesh instance per glyph) instead of
+// each issuing its own draw call.
+type TextRun3D struct {
+	Text    string       `desc:"the text string to shape"`
+	Face    string       `desc:"font face name (family + style)"`
+	Size    int          `desc:"font size in pixels"`
+	Color   gi.Color     `desc:"fill color for the glyph quads"`
+	Quads   []GlyphQuad  `view:"-" desc:"shaped glyph quads, one per rune (spaces included), relative to the run's origin"`
+	Bounds  mat32.Vec2   `desc:"overall width/height of the shaped run, in world units"`
+	cache   *GlyphCache
+}
+
+// NewTextRun3D creates a TextRun3D that shapes against the given cache.
+func NewTextRun3D(cache *GlyphCache, face string, size int) *TextRun3D {
+	return &TextRun3D{Face: face, Size: size, cache: cache}
+}
+
+// Shape lays out text into Quads, rasterizing any glyphs not already
+// resident in the run's GlyphCache via rasterize. rasterize must return
+// the rune's bitmap, bearing, and advance at this run's Size before
+// kerning -- callers typically supply a closure over a font face lookup.
+func (tr *TextRun3D) Shape(text string, unitsPerPx float32, rasterize func(face string, size int, r rune) (img *image.RGBA, bearing image.Point, advance float32)) error {
+	tr.Text = text
+	tr.Quads = tr.Quads[:0]
+	var pen float32
+	var maxH float32
+	for _, r := range text { // range over string iterates runes, not bytes
+		key := GlyphKey{Face: tr.Face, Size: tr.Size, Rune: r}
+		gr, err := tr.cache.Glyph(key, func() (*image.RGBA, image.Point, float32) {
+			return rasterize(tr.Face, tr.Size, r)
+		})
+		if err != nil {
+			return err
+		}
+		w := float32(gr.Size.X) * unitsPerPx
+		h := float32(gr.Size.Y) * unitsPerPx
+		tr.Quads = append(tr.Quads, GlyphQuad{
+			Pos:   mat32.Vec3{pen + float32(gr.Bearing.X)*unitsPerPx, -float32(gr.Bearing.Y) * unitsPerPx, 0},
+			Size:  mat32.Vec2{w, h},
+			UVMin: gr.UVMin,
+			UVMax: gr.UVMax,
+		})
+		pen += gr.Advance * unitsPerPx
+		if h > maxH {
+			maxH = h
+		}
+	}
+	tr.Bounds = mat32.Vec2{pen, maxH}
+	return nil
+}
@@ -0,0 +1,142 @@
+// Copyright (c) 2019, The GoKi Authors. All rights reserved.
+// Use of this source code is governed by a BSD-style
+// license that can be found in the LICENSE file.
+
+package gi3d
+
+import (
+	"fmt"
+
+	"github.com/goki/gi/gi"
+	"github.com/goki/gi/mat32"
+	"github.com/goki/gi/oswin/gpu"
+)
+
+// InVtxInstMat and InVtxInstColor extend the Scene's per-vertex-attribute
+// index set (alongside InVtxPos, InVtxNorm, InVtxTex, InVtxColor) to cover
+// the per-instance attributes InstancedMesh binds at divisor 1. Scene's
+// render program setup must declare vectors at these indices for the
+// instanced shader variant to find them.
+const (
+	InVtxInstMat = InVtxColor + 1 + iota
+	InVtxInstColor
+)
+
+// Instance holds the per-instance data uploaded alongside a MeshBase's
+// shared vertex/index buffer when rendered via InstancedMesh -- at
+// minimum a model matrix, plus an optional per-instance color so e.g. a
+// particle system or a forest of trees can vary tint without vertex
+// color data in the base mesh itself.
+type Instance struct {
+	Mat   mat32.Mat4 `desc:"model matrix for this instance, composed the same way as Pose.Matrix for an ordinary Object"`
+	Color mat32.Vec4 `desc:"per-instance color multiplier -- ignored unless the InstancedMesh's UseColor is true"`
+}
+
+// InstancedMesh reuses a single MeshBase's vertex/index buffer across
+// many instances, each described by an Instance (model matrix + optional
+// color) bound at attribute divisor 1. Callers that would otherwise
+// create N Object children all pointing at the same Mesh -- e.g.
+// particles, trees, neurons, or glyph quads -- can instead create one
+// InstancedMesh and call SetInstances, cutting per-object draw-call and
+// CPU dispatch overhead by roughly the instance count.
+type InstancedMesh struct {
+	MeshBase
+	UseColor  bool          `desc:"if true, the per-instance Color is bound and read by the instanced shader variant -- if false, all instances use the mesh/material's ordinary color"`
+	Instances []Instance    `desc:"current per-instance data -- set via SetInstances, or update individual entries via UpdateInstance"`
+	InstBuff  gpu.VectorsBuffer `view:"-" desc:"per-instance vertex buffer (mat4 + optional vec4), bound at attribute divisor 1 -- separate from MeshBase.Buff, which holds the shared per-vertex data"`
+}
+
+// NewInstancedMesh creates an empty InstancedMesh with the given name and
+// no instances yet -- call SetInstances before the first Render3DInstanced.
+func NewInstancedMesh(name string) *InstancedMesh {
+	im := &InstancedMesh{}
+	im.Nm = name
+	return im
+}
+
+// SetInstances replaces the full set of per-instance data and marks the
+// instance buffer for a full re-transfer on the next Render3DInstanced.
+func (im *InstancedMesh) SetInstances(insts []Instance) {
+	im.Instances = insts
+	if im.InstBuff != nil {
+		im.InstBuff.SetLen(len(insts))
+	}
+}
+
+// UpdateInstance updates a single instance's data in place, returning an
+// error if i is out of range. The whole instance buffer is still rebuilt
+// and re-transferred on the next Render3DInstanced, as after SetInstances.
+func (im *InstancedMesh) UpdateInstance(i int, inst Instance) error {
+	if i < 0 || i >= len(im.Instances) {
+		return fmt.Errorf("gi3d.InstancedMesh: %v instance index %d out of range (%d instances)", im.Nm, i, len(im.Instances))
+	}
+	im.Instances[i] = inst
+	return nil
+}
+
+// activateInstances ensures the per-instance buffer exists and its data
+// is current, creating the gpu.VectorsBuffer (bound at attribute
+// divisor 1) the first time it is called.
+func (im *InstancedMesh) activateInstances(sc *Scene) {
+	if im.InstBuff == nil {
+		usg := gpu.DynamicDraw
+		im.InstBuff = gpu.TheGPU.NewVectorsBuffer(usg)
+		im.InstBuff.SetDivisor(1)
+		instMat := sc.Renders.Vectors[InVtxInstMat]
+		im.InstBuff.AddVectors(instMat, true)
+		if im.UseColor {
+			instClr := sc.Renders.Vectors[InVtxInstColor]
+			im.InstBuff.AddVectors(instClr, false)
+		}
+	}
+	n := len(im.Instances)
+	im.InstBuff.SetLen(n)
+
+	mats := make([]float32, 0, n*16)
+	for _, inst := range im.Instances {
+		mats = append(mats, inst.Mat[:]...)
+	}
+	im.InstBuff.SetVecData(sc.Renders.Vectors[InVtxInstMat], mats)
+
+	if im.UseColor {
+		clrs := make([]float32, 0, n*4)
+		for _, inst := range im.Instances {
+			clrs = append(clrs, inst.Color[:]...)
+		}
+		im.InstBuff.SetVecData(sc.Renders.Vectors[InVtxInstColor], clrs)
+	}
+	im.InstBuff.TransferAll()
+}
+
+// Render3DInstanced activates both the shared mesh buffer and the
+// per-instance buffer, then issues a single
+// gpu.Draw.TrianglesIndexedInstanced call covering all instances. sc's
+// render program set must have compiled the instanced shader variant
+// (see Scene program setup) which reads gl_InstanceID-indexed matrices
+// instead of a single per-object uniform.
+func (im *InstancedMesh) Render3DInstanced(sc *Scene) {
+	if len(im.Instances) == 0 {
+		return
+	}
+	im.Activate(sc)
+	im.activateInstances(sc)
+	ibuf := im.Buff.IndexesBuffer()
+	gpu.Draw.TrianglesIndexedInstanced(0, ibuf.Len(), len(im.Instances))
+}
+
+// InstanceMatrix builds the model matrix for one instance the same way
+// Pose.Matrix is built for an ordinary Object -- a convenience for
+// callers populating Instances from pos/quat/scale triples rather than
+// hand-composed matrices.
+func InstanceMatrix(pos mat32.Vec3, quat mat32.Quat, scale mat32.Vec3) mat32.Mat4 {
+	var m mat32.Mat4
+	m.SetTransform(pos, quat, scale)
+	return m
+}
+
+// InstanceColor packs a gi.Color into the vec4 format expected by
+// Instance.Color, matching ColorToVec4f used elsewhere for per-vertex
+// colors.
+func InstanceColor(clr gi.Color) mat32.Vec4 {
+	return ColorToVec4f(clr)
+}
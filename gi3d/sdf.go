@@ -0,0 +1,194 @@
+// Copyright (c) 2019, The GoKi Authors. All rights reserved.
+// Use of this source code is governed by a BSD-style
+// license that can be found in the LICENSE file.
+
+package gi3d
+
+import (
+	"image"
+	"image/color"
+	"image/draw"
+)
+
+// RClassOpaqueTextureSDF and RClassTransTextureSDF select the SDF
+// fragment shader (TextSDFFragShader, named by TextSDFProgName)
+// instead of the plain textured-quad shader used by RClassOpaqueTexture /
+// RClassTransTexture. Offset well past the existing RenderClasses range
+// so adding them here cannot collide with classes defined elsewhere.
+const (
+	RClassOpaqueTextureSDF RenderClasses = iota + 100
+	RClassTransTextureSDF
+)
+
+// SDFSpread is the default spread (in pixels) used to normalize
+// signed distance values into the [0,1] range stored in the SDF texture.
+// Larger spreads produce smoother edges and a wider outline/glow band,
+// but require sampling further from the glyph edge to stay accurate.
+const SDFSpread = 8
+
+// sdfPoint holds an offset vector (in pixels) to the nearest opposite-sign
+// pixel, as used by the 8-points signed sequential Euclidean distance
+// transform (8SSEDT).
+type sdfPoint struct {
+	dx, dy int
+}
+
+var sdfInside = sdfPoint{0, 0}
+var sdfEmpty = sdfPoint{1 << 20, 1 << 20} // effectively +Inf
+
+func (p sdfPoint) distSq() int {
+	return p.dx*p.dx + p.dy*p.dy
+}
+
+// sdfCompare replaces cur with other (offset by dx,dy from the neighbor
+// that other was computed for) if the resulting vector is shorter.
+func sdfCompare(grid []sdfPoint, w, h, x, y, dx, dy int) {
+	nx, ny := x+dx, y+dy
+	if nx < 0 || nx >= w || ny < 0 || ny >= h {
+		return
+	}
+	other := grid[ny*w+nx]
+	other.dx += dx
+	other.dy += dy
+	if other.distSq() < grid[y*w+x].distSq() {
+		grid[y*w+x] = other
+	}
+}
+
+// sdf8SSEDT computes the 8SSEDT grid of nearest-opposite-pixel offset
+// vectors for a boolean inside/outside mask (true = inside the glyph).
+// It performs the standard two-pass sweep: forward (top-left to
+// bottom-right) propagating from N / W / NW / NE neighbors, then backward
+// (bottom-right to top-left) propagating from S / E / SE / SW neighbors.
+func sdf8SSEDT(inside []bool, w, h int) []sdfPoint {
+	grid := make([]sdfPoint, w*h)
+	for i, in := range inside {
+		if in {
+			grid[i] = sdfEmpty
+		} else {
+			grid[i] = sdfInside
+		}
+	}
+
+	// forward pass: N, W, NW, NE
+	for y := 0; y < h; y++ {
+		for x := 0; x < w; x++ {
+			sdfCompare(grid, w, h, x, y, 0, -1)
+			sdfCompare(grid, w, h, x, y, -1, 0)
+			sdfCompare(grid, w, h, x, y, -1, -1)
+			sdfCompare(grid, w, h, x, y, 1, -1)
+		}
+	}
+	// backward pass: S, E, SE, SW
+	for y := h - 1; y >= 0; y-- {
+		for x := w - 1; x >= 0; x-- {
+			sdfCompare(grid, w, h, x, y, 0, 1)
+			sdfCompare(grid, w, h, x, y, 1, 0)
+			sdfCompare(grid, w, h, x, y, 1, 1)
+			sdfCompare(grid, w, h, x, y, -1, 1)
+		}
+	}
+	return grid
+}
+
+// GenerateSDF computes a signed distance field from a rasterized glyph
+// mask, using the two-pass 8SSEDT algorithm run once on the inside mask
+// and once on the outside (inverse) mask. The per-pixel distance is
+// sign * sqrt(dx² + dy²), normalized by spread into [0,1] and centered
+// at 0.5 (the glyph edge), ready for smoothstep-based reconstruction in
+// the fragment shader. spread is the distance (in pixels) from the edge
+// at which the value reaches 0 (outside) or 1 (inside), so the full 0..1
+// range spans 2*spread pixels -- SDFSpread is a reasonable default.
+func GenerateSDF(mask *image.Alpha, spread int) *image.Alpha {
+	b := mask.Bounds()
+	w, h := b.Dx(), b.Dy()
+	inside := make([]bool, w*h)
+	for y := 0; y < h; y++ {
+		for x := 0; x < w; x++ {
+			inside[y*w+x] = mask.AlphaAt(b.Min.X+x, b.Min.Y+y).A >= 128
+		}
+	}
+	outsideOf := make([]bool, w*h)
+	for i, in := range inside {
+		outsideOf[i] = !in
+	}
+
+	insideGrid := sdf8SSEDT(inside, w, h)
+	outsideGrid := sdf8SSEDT(outsideOf, w, h)
+
+	sdf := image.NewAlpha(image.Rect(0, 0, w, h))
+	fspread := float32(spread)
+	for y := 0; y < h; y++ {
+		for x := 0; x < w; x++ {
+			idx := y*w + x
+			var dist float32
+			if inside[idx] {
+				dist = -sqrtInt(insideGrid[idx].distSq())
+			} else {
+				dist = sqrtInt(outsideGrid[idx].distSq())
+			}
+			norm := 0.5 - dist/(2*fspread)
+			if norm < 0 {
+				norm = 0
+			} else if norm > 1 {
+				norm = 1
+			}
+			sdf.SetAlpha(x, y, color.Alpha{A: uint8(norm * 255)})
+		}
+	}
+	return sdf
+}
+
+func sqrtInt(v int) float32 {
+	if v <= 0 {
+		return 0
+	}
+	x := float32(v)
+	// a few rounds of Newton's method is plenty accurate for SDF generation
+	g := x
+	for i := 0; i < 8; i++ {
+		g = 0.5 * (g + x/g)
+	}
+	return g
+}
+
+// alphaMaskFromRGBA extracts the alpha channel of img as a standalone mask,
+// suitable for passing to GenerateSDF. Text is typically rendered with an
+// opaque glyph color onto a transparent background, so alpha directly
+// reflects glyph coverage.
+func alphaMaskFromRGBA(img *image.RGBA) *image.Alpha {
+	b := img.Bounds()
+	mask := image.NewAlpha(b)
+	draw.Draw(mask, b, img, b.Min, draw.Src)
+	return mask
+}
+
+// TextSDFFragShader is the fragment shader source used to render an SDF
+// text texture. This package does not compile it or add it to the
+// Scene's render program set. w is derived per-fragment from fwidth(sdf) so that
+// edges stay crisp regardless of the object's world-space scale.
+const TextSDFFragShader = `
+#version 330
+uniform sampler2D Tex;
+uniform vec4 Color;
+uniform bool Outline;
+uniform float OutlineDist;
+uniform vec4 OutlineColor;
+in vec2 TexCoord;
+out vec4 FragColor;
+void main() {
+	float sdf = texture(Tex, TexCoord).a;
+	float w = fwidth(sdf);
+	float alpha = smoothstep(0.5 - w, 0.5 + w, sdf);
+	vec4 col = vec4(Color.rgb, Color.a * alpha);
+	if (Outline) {
+		float oalpha = smoothstep(OutlineDist - w, OutlineDist + w, sdf);
+		col = mix(vec4(OutlineColor.rgb, OutlineColor.a * oalpha), col, alpha);
+	}
+	FragColor = col;
+}
+`
+
+// TextSDFProgName is the program name for TextSDFFragShader. Nothing in
+// this package registers a program under this name in Scene.Renders.
+const TextSDFProgName = "text2d-sdf"
@@ -26,6 +26,8 @@ import (
 type Text2D struct {
 	Object
 	Text      string        `desc:"the text string to display"`
+	SDF       bool          `desc:"if true, render the text into a signed-distance-field texture and sample it with a smoothstep-based fragment shader, so the text stays sharp at any 3D scale instead of blurring or pixelating -- recommended for text that is scaled dynamically or viewed across a range of distances"`
+	SDFSpread int           `desc:"spread in pixels used to normalize the SDF distance values -- only used if SDF is true -- larger values give smoother edges but need a correspondingly larger source raster size"`
 	Sty       gi.Style      `json:"-" xml:"-" desc:"styling settings for the text"`
 	TxtPos    gi.Vec2D      `xml:"-" json:"-" desc:"position offset of start of text rendering relative to upper-left corner"`
 	TxtRender gi.TextRender `view:"-" xml:"-" json:"-" desc:"render data for text label"`
@@ -52,6 +54,7 @@ func (txt *Text2D) Defaults() {
 	txt.SetProp("color", "black")
 	txt.SetProp("background-color", gi.Color{0, 0, 0, 0})
 	txt.Mat.Bright = 5 // this is key for making e.g., a white background show up as white..
+	txt.SDFSpread = SDFSpread
 }
 
 func (txt *Text2D) Init3D(sc *Scene) {
@@ -113,10 +116,66 @@ func (txt *Text2D) RenderText(sc *Scene) {
 	txt.TxtRender.Render(rs, txt.TxtPos)
 	rs.PopBounds()
 	rs.Image = nil
+	if txt.SDF {
+		txt.renderSDF(sc, img)
+	} else {
+		txt.TxtTex.Tex.SetImage(img)
+	}
 	txt.Mat.SetTexture(sc, txt.TxtTex)
 	gi.SavePNG("text-test.png", img)
 }
 
+// SetRunes sets Text from a slice of runes and calls RenderText to
+// update the rendered texture. Audited against Text2D.RenderText and the
+// gi.TextRender.SetHTML / LayoutStdLR paths it calls: Text is a Go
+// string (always UTF-8) and every layer here already iterates it as
+// runes rather than bytes, so SetRunes is provided as a convenience for
+// callers that build up text rune-by-rune (e.g. from a TextRun3D-style
+// shaper) rather than as a fix for byte-indexed glyph lookup -- there is
+// no such indexing in this path.
+func (txt *Text2D) SetRunes(sc *Scene, rs []rune) {
+	txt.Text = string(rs)
+	txt.RenderText(sc)
+}
+
+// Metrics returns ascent, descent, line-height and total advance (the
+// overall rendered width) of the current Text, all in world units
+// (i.e. already multiplied by Pose.Scale), so 3D layout code can
+// position labels relative to one another without re-rasterizing just
+// to learn their size.
+func (txt *Text2D) Metrics() (ascent, descent, lineHeight, advance float32) {
+	sc := txt.Pose.Scale.X
+	fh := txt.Sty.Font.Face.Metrics.Height
+	asc := txt.Sty.Font.Face.Metrics.Ascent
+	desc := fh - asc
+	ascent = asc * sc
+	descent = desc * sc
+	lineHeight = fh * sc
+	if txt.TxtTex != nil {
+		advance = float32(txt.TxtTex.Tex.Size().X) * sc
+	}
+	return
+}
+
+// renderSDF replaces the rasterized glyph mask with a signed-distance-field
+// texture generated via GenerateSDF, so the fragment shader selected by
+// RenderClass (TextSDFProgName, reconstructed via smoothstep) can
+// reproduce sharp edges at any scale. The fragment shader only ever
+// samples the texture's alpha channel (see TextSDFFragShader), so the
+// SDF is written straight into an image.Alpha rather than baking any
+// color into RGB -- an outline/glow band would need Text2D to actually
+// set the shader's Outline/OutlineDist/OutlineColor uniforms through the
+// Material, which this package doesn't yet expose a way to do.
+func (txt *Text2D) renderSDF(sc *Scene, img *image.RGBA) {
+	spread := txt.SDFSpread
+	if spread <= 0 {
+		spread = SDFSpread
+	}
+	mask := alphaMaskFromRGBA(img)
+	sdf := GenerateSDF(mask, spread)
+	txt.TxtTex.Tex.SetImage(sdf)
+}
+
 // Validate checks that object has valid mesh and texture settings, etc
 func (txt *Text2D) Validate(sc *Scene) error {
 	// todo: validate more stuff here
@@ -144,6 +203,12 @@ func (txt *Text2D) IsTransparent() bool {
 }
 
 func (txt *Text2D) RenderClass() RenderClasses {
+	if txt.SDF {
+		if txt.IsTransparent() {
+			return RClassTransTextureSDF
+		}
+		return RClassOpaqueTextureSDF
+	}
 	if txt.IsTransparent() {
 		return RClassTransTexture
 	}
@@ -0,0 +1,52 @@
+// Copyright (c) 2019, The GoKi Authors. All rights reserved.
+// Use of this source code is governed by a BSD-style
+// license that can be found in the LICENSE file.
+
+package gi3d
+
+import (
+	"testing"
+
+	"github.com/goki/gi/gi"
+)
+
+// FuzzRenderTextShaping feeds UTF-8 strings -- starting from the seed
+// corpus below (combining marks, RTL scripts, 4-byte runes) and explored
+// further by `go test -fuzz=FuzzRenderTextShaping` -- through the same
+// gi.TextRender.SetHTML / LayoutStdLR pair Text2D.RenderText calls, and
+// asserts shaping never panics and never produces a negative or
+// implausibly large size. A real *Scene (and the gpu-backed RenderState
+// RenderText needs to rasterize) isn't constructible in this package's
+// test environment, so the shaping/layout step is exercised directly
+// rather than through RenderText/SetRunes themselves.
+func FuzzRenderTextShaping(f *testing.F) {
+	seeds := []string{
+		"hello world",
+		"éàôüñ", // combining marks
+		"אבגדהוז",    // Hebrew (RTL)
+		"ابتثجحخ",    // Arabic (RTL)
+		"\U0001F600\U0001F601\U0001F602\U0001F923\U0001F60E", // 4-byte emoji
+		"",
+	}
+	for _, s := range seeds {
+		f.Add(s)
+	}
+
+	var sty gi.Style
+	sty.Defaults()
+	sty.SetUnitContext(nil, gi.Vec2DZero)
+
+	f.Fuzz(func(t *testing.T, s string) {
+		var tr gi.TextRender
+		tr.SetHTML(s, &sty.Font, &sty.Text, &sty.UnContext, nil)
+		sz := tr.Size
+		tr.LayoutStdLR(&sty.Text, &sty.Font, &sty.UnContext, sz)
+		if tr.Size.X < 0 || tr.Size.Y < 0 {
+			t.Fatalf("negative layout size %v for %q", tr.Size, s)
+		}
+		const maxReasonable = 1 << 20
+		if tr.Size.X > maxReasonable || tr.Size.Y > maxReasonable {
+			t.Fatalf("implausible layout size %v for %q", tr.Size, s)
+		}
+	})
+}
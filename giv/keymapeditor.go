@@ -0,0 +1,225 @@
+// Copyright (c) 2019, The GoKi Authors. All rights reserved.
+// Use of this source code is governed by a BSD-style
+// license that can be found in the LICENSE file.
+
+package giv
+
+import (
+	"sort"
+
+	"github.com/goki/gi"
+	"github.com/goki/gi/units"
+	"github.com/goki/ki/ki"
+	"github.com/goki/ki/kit"
+)
+
+// KeyMapEditor opens a window listing every action in km with its bound
+// key chord. Clicking a row and then pressing a new chord rebinds that
+// action (capturing modifiers + key); conflicting chords are flagged
+// so the user notices before two actions both fire on the same press.
+// Launched from the "Edit KeyMap" button in PrefsEditor. km is scoped to
+// a single context (e.g. the global map, or a TextView-specific map) --
+// open a separate KeyMapEditor per context to edit more than one.
+func KeyMapEditor(km *gi.KeyMap) {
+	width := 600
+	height := 700
+	win := gi.NewWindow2D("gogi-keymap-editor", "Key Map Editor", width, height, true)
+
+	vp := win.WinViewport2D()
+	updt := vp.UpdateStart()
+	vp.Fill = true
+
+	vlay := vp.AddNewChild(gi.KiT_Frame, "vlay").(*gi.Frame)
+	vlay.Lay = gi.LayoutCol
+
+	trow := vlay.AddNewChild(gi.KiT_Layout, "trow").(*gi.Layout)
+	trow.Lay = gi.LayoutRow
+	trow.SetStretchMaxWidth()
+	title := trow.AddNewChild(gi.KiT_Label, "title").(*gi.Label)
+	title.Text = "Key Map Editor"
+	title.SetStretchMaxWidth()
+
+	spc := vlay.AddNewChild(gi.KiT_Space, "spc1").(*gi.Space)
+	spc.SetFixedHeight(units.NewValue(1.0, units.Em))
+
+	kv := vlay.AddNewChild(KiT_KeyMapView, "kv").(*KeyMapView)
+	kv.SetKeyMap(km)
+	kv.SetStretchMaxWidth()
+	kv.SetStretchMaxHeight()
+
+	bspc := vlay.AddNewChild(gi.KiT_Space, "ButSpc").(*gi.Space)
+	bspc.SetFixedHeight(units.NewValue(1.0, units.Em))
+
+	brow := vlay.AddNewChild(gi.KiT_Layout, "brow").(*gi.Layout)
+	brow.Lay = gi.LayoutRow
+	brow.SetProp("align-horiz", "center")
+	brow.SetStretchMaxWidth()
+
+	exp := brow.AddNewChild(gi.KiT_Button, "export").(*gi.Button)
+	exp.SetText("Export...")
+	exp.ButtonSig.Connect(win.This, func(recv, send ki.Ki, sig int64, data interface{}) {
+		if sig == int64(gi.ButtonClicked) {
+			gi.FileViewDialog(vp, gi.Prefs.PrefsDir, "keymap.toml", "Export KeyMap", ".json,.toml,.yaml,.yml", win.This, func(recv, send ki.Ki, sig int64, data interface{}) {
+				if sig != int64(gi.DialogAccepted) {
+					return
+				}
+				path := gi.FileViewDialogValue(send)
+				if path == "" {
+					return
+				}
+				if err := SaveStructAs(km, path, PrefsFormatFromExt(path)); err != nil {
+					gi.PromptDialog(vp, gi.DlgOpts{Title: "Error Exporting KeyMap"}, true, false, err.Error(), nil, nil)
+				}
+			})
+		}
+	})
+
+	imp := brow.AddNewChild(gi.KiT_Button, "import").(*gi.Button)
+	imp.SetText("Import...")
+	imp.ButtonSig.Connect(win.This, func(recv, send ki.Ki, sig int64, data interface{}) {
+		if sig == int64(gi.ButtonClicked) {
+			gi.FileViewDialog(vp, gi.Prefs.PrefsDir, "", "Import KeyMap", ".json,.toml,.yaml,.yml", win.This, func(recv, send ki.Ki, sig int64, data interface{}) {
+				if sig != int64(gi.DialogAccepted) {
+					return
+				}
+				path := gi.FileViewDialogValue(send)
+				if path == "" {
+					return
+				}
+				if err := LoadStructFrom(km, path, PrefsFormatFromExt(path)); err != nil {
+					gi.PromptDialog(vp, gi.DlgOpts{Title: "Error Importing KeyMap"}, true, false, err.Error(), nil, nil)
+					return
+				}
+				kv.UpdateFromKeyMap()
+			})
+		}
+	})
+
+	done := brow.AddNewChild(gi.KiT_Button, "done").(*gi.Button)
+	done.SetText("Done")
+	done.ButtonSig.Connect(win.This, func(recv, send ki.Ki, sig int64, data interface{}) {
+		if sig == int64(gi.ButtonClicked) {
+			gi.Prefs.Save()
+			win.OSWin.Close()
+		}
+	})
+
+	vp.UpdateEndNoSig(updt)
+	win.GoStartEventLoop()
+}
+
+// KeyMapView displays and edits the bindings in a gi.KeyMap: one row per
+// action, each showing the action name and its current chord. Clicking a
+// row's chord button arms it to capture the next key press (via
+// KeyChordEvent) as its new binding; capturing a chord already used by
+// another action in the same context flags both rows as conflicting
+// rather than silently overwriting.
+type KeyMapView struct {
+	gi.Frame
+	KeyMap    *gi.KeyMap  `desc:"the key map being edited"`
+	Armed     string      `view:"-" desc:"action name currently waiting to capture a new chord, or empty"`
+	ArmedBut  *gi.Button  `view:"-" desc:"chord button currently armed, or nil -- used to restore its label if a different row is armed before it captures a chord"`
+	ArmedOrig string      `view:"-" desc:"ArmedBut's label before it was armed, restored if it's abandoned in favor of a different row"`
+	Conflict map[string]bool `view:"-" desc:"action names whose current chord collides with another action's -- used to flag rows in red"`
+}
+
+var KiT_KeyMapView = kit.Types.AddType(&KeyMapView{}, nil)
+
+// SetKeyMap installs km and (re)builds the row widgets.
+func (kv *KeyMapView) SetKeyMap(km *gi.KeyMap) {
+	kv.KeyMap = km
+	kv.Lay = gi.LayoutCol
+	kv.UpdateFromKeyMap()
+}
+
+// UpdateFromKeyMap rebuilds one row per action in KeyMap, sorted by
+// action name for a stable, scannable listing, and recomputes Conflict.
+func (kv *KeyMapView) UpdateFromKeyMap() {
+	updt := kv.UpdateStart()
+	kv.DeleteChildren(true)
+	kv.recomputeConflicts()
+
+	names := make([]string, 0, len(*kv.KeyMap))
+	for act := range *kv.KeyMap {
+		names = append(names, act)
+	}
+	sort.Strings(names)
+
+	for _, act := range names {
+		kv.addRow(act)
+	}
+	kv.UpdateEnd(updt)
+}
+
+// recomputeConflicts finds every pair of actions bound to the identical
+// chord within this map and records both as conflicting.
+func (kv *KeyMapView) recomputeConflicts() {
+	kv.Conflict = make(map[string]bool)
+	byChord := make(map[string][]string)
+	for act, chord := range *kv.KeyMap {
+		byChord[chord] = append(byChord[chord], act)
+	}
+	for chord, acts := range byChord {
+		if chord == "" || len(acts) < 2 {
+			continue
+		}
+		for _, act := range acts {
+			kv.Conflict[act] = true
+		}
+	}
+}
+
+// addRow adds one row (action label + rebindable chord button) for act.
+func (kv *KeyMapView) addRow(act string) {
+	row := kv.AddNewChild(gi.KiT_Layout, "row-"+act).(*gi.Layout)
+	row.Lay = gi.LayoutRow
+	row.SetStretchMaxWidth()
+
+	lbl := row.AddNewChild(gi.KiT_Label, "label").(*gi.Label)
+	lbl.Text = act
+	lbl.SetStretchMaxWidth()
+	if kv.Conflict[act] {
+		lbl.SetProp("color", "red")
+	}
+
+	chordBut := row.AddNewChild(gi.KiT_Button, "chord").(*gi.Button)
+	chordBut.SetText((*kv.KeyMap)[act])
+	chordBut.ButtonSig.Connect(kv.This(), func(recv, send ki.Ki, sig int64, data interface{}) {
+		if sig != int64(gi.ButtonClicked) {
+			return
+		}
+		b := send.(*gi.Button)
+		kv.arm(act, b)
+	})
+	// KeyChordEventType is connected once per button here, rather than
+	// inside arm, so repeatedly arming the same row doesn't stack
+	// duplicate handlers -- the kv.Armed != act guard below makes the
+	// handler inert except while this specific row is armed.
+	chordBut.ConnectEvent(gi.KeyChordEventType, gi.HiPri, func(recv, send ki.Ki, sig int64, data interface{}) {
+		if kv.Armed != act {
+			return
+		}
+		ke := data.(*gi.KeyChordEvent)
+		chord := ke.Chord()
+		ke.SetProcessed()
+		kv.Armed = ""
+		kv.ArmedBut = nil
+		(*kv.KeyMap)[act] = string(chord)
+		kv.UpdateFromKeyMap()
+	})
+}
+
+// arm puts act into chord-capture mode: the next KeyChordEvent delivered
+// to but is taken as the new binding instead of being processed normally,
+// mirroring how text fields capture the next keystroke while focused. If
+// a different row was already armed, its button's original label is
+// restored first so it doesn't get stuck reading "(press keys...)".
+func (kv *KeyMapView) arm(act string, but *gi.Button) {
+	if kv.ArmedBut != nil && kv.ArmedBut != but {
+		kv.ArmedBut.SetText(kv.ArmedOrig)
+	}
+	kv.Armed = act
+	kv.ArmedBut = but
+	kv.ArmedOrig = but.Text
+	but.SetText("(press keys...)")
+}
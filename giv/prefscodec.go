@@ -0,0 +1,139 @@
+// Copyright (c) 2019, The GoKi Authors. All rights reserved.
+// Use of this source code is governed by a BSD-style
+// license that can be found in the LICENSE file.
+
+package giv
+
+import (
+	"encoding/json"
+	"fmt"
+	"io/ioutil"
+	"path/filepath"
+	"strings"
+
+	"github.com/BurntSushi/toml"
+	"gopkg.in/yaml.v2"
+)
+
+// PrefsFormat is a file format a PrefsEditor can save/load preferences
+// (or any other struct) as.
+type PrefsFormat int
+
+const (
+	// PrefsJSON is the original format used by gi.Preferences.Save/Load.
+	PrefsJSON PrefsFormat = iota
+
+	// PrefsTOML is a human-editable alternative, handy for hand-written
+	// config snippets.
+	PrefsTOML
+
+	// PrefsYAML is a human-editable alternative, handy for hand-written
+	// config snippets.
+	PrefsYAML
+
+	PrefsFormatN
+)
+
+//go:generate stringer -type=PrefsFormat
+
+// PrefsFormatFromExt guesses a PrefsFormat from a file path's extension,
+// defaulting to PrefsJSON if the extension is unrecognized.
+func PrefsFormatFromExt(path string) PrefsFormat {
+	switch strings.ToLower(filepath.Ext(path)) {
+	case ".toml":
+		return PrefsTOML
+	case ".yaml", ".yml":
+		return PrefsYAML
+	default:
+		return PrefsJSON
+	}
+}
+
+// SaveStructAs saves v (typically a *gi.Preferences) to path in the
+// given format. It round-trips through JSON internally for the non-JSON
+// formats, so it works with any struct whose fields already have the
+// json tags gi.Preferences defines, without needing toml/yaml-specific
+// tags as well.
+func SaveStructAs(v interface{}, path string, format PrefsFormat) error {
+	switch format {
+	case PrefsJSON:
+		b, err := json.MarshalIndent(v, "", "  ")
+		if err != nil {
+			return err
+		}
+		return ioutil.WriteFile(path, b, 0644)
+	case PrefsTOML, PrefsYAML:
+		generic, err := toGenericMap(v)
+		if err != nil {
+			return err
+		}
+		var b []byte
+		if format == PrefsTOML {
+			var sb strings.Builder
+			if err := toml.NewEncoder(&sb).Encode(generic); err != nil {
+				return err
+			}
+			b = []byte(sb.String())
+		} else {
+			b, err = yaml.Marshal(generic)
+			if err != nil {
+				return err
+			}
+		}
+		return ioutil.WriteFile(path, b, 0644)
+	default:
+		return fmt.Errorf("giv.SaveStructAs: unknown format %v", format)
+	}
+}
+
+// LoadStructFrom loads v (typically a *gi.Preferences) from path in the
+// given format, the inverse of SaveStructAs.
+func LoadStructFrom(v interface{}, path string, format PrefsFormat) error {
+	b, err := ioutil.ReadFile(path)
+	if err != nil {
+		return err
+	}
+	switch format {
+	case PrefsJSON:
+		return json.Unmarshal(b, v)
+	case PrefsTOML:
+		var generic map[string]interface{}
+		if _, err := toml.Decode(string(b), &generic); err != nil {
+			return err
+		}
+		return fromGenericMap(generic, v)
+	case PrefsYAML:
+		var generic map[string]interface{}
+		if err := yaml.Unmarshal(b, &generic); err != nil {
+			return err
+		}
+		return fromGenericMap(generic, v)
+	default:
+		return fmt.Errorf("giv.LoadStructFrom: unknown format %v", format)
+	}
+}
+
+// toGenericMap converts v to a map[string]interface{} via JSON, so TOML
+// and YAML encoders (which don't understand v's concrete type or its
+// json tags directly) see the same field names JSON does.
+func toGenericMap(v interface{}) (map[string]interface{}, error) {
+	b, err := json.Marshal(v)
+	if err != nil {
+		return nil, err
+	}
+	var generic map[string]interface{}
+	if err := json.Unmarshal(b, &generic); err != nil {
+		return nil, err
+	}
+	return generic, nil
+}
+
+// fromGenericMap re-encodes generic as JSON and unmarshals it into v, so
+// the same json tags drive decoding regardless of the source format.
+func fromGenericMap(generic map[string]interface{}, v interface{}) error {
+	b, err := json.Marshal(generic)
+	if err != nil {
+		return err
+	}
+	return json.Unmarshal(b, v)
+}
@@ -36,10 +36,17 @@ func PrefsEditor(p *gi.Preferences) {
 	title.SetStretchMaxWidth()
 	trow.AddNewChild(gi.KiT_Stretch, "str2")
 
+	srow := vlay.AddNewChild(gi.KiT_Layout, "srow").(*gi.Layout)
+	srow.Lay = gi.LayoutRow
+	srow.SetStretchMaxWidth()
+
 	sv := vlay.AddNewChild(KiT_StructView, "sv").(*StructView)
 	sv.SetStruct(p, nil)
 	sv.SetStretchMaxWidth()
 	sv.SetStretchMaxHeight()
+	sv.BeginTransaction(p.Update)
+
+	AddSearchBar(srow, sv)
 
 	bspc := vlay.AddNewChild(gi.KiT_Space, "ButSpc").(*gi.Space)
 	bspc.SetFixedHeight(units.NewValue(1.0, units.Em))
@@ -49,27 +56,81 @@ func PrefsEditor(p *gi.Preferences) {
 	brow.SetProp("align-horiz", "center")
 	brow.SetStretchMaxWidth()
 
-	up := brow.AddNewChild(gi.KiT_Button, "update").(*gi.Button)
-	up.SetText("Update")
-	up.ButtonSig.Connect(win.This, func(recv, send ki.Ki, sig int64, data interface{}) {
+	apply := brow.AddNewChild(gi.KiT_Button, "apply").(*gi.Button)
+	apply.SetText("Apply")
+	apply.ButtonSig.Connect(win.This, func(recv, send ki.Ki, sig int64, data interface{}) {
 		if sig == int64(gi.ButtonClicked) {
 			p.Update()
+			sv.Commit()
+			sv.BeginTransaction(p.Update) // start a fresh transaction from the now-applied state
+		}
+	})
+
+	revert := brow.AddNewChild(gi.KiT_Button, "revert").(*gi.Button)
+	revert.SetText("Revert")
+	revert.ButtonSig.Connect(win.This, func(recv, send ki.Ki, sig int64, data interface{}) {
+		if sig == int64(gi.ButtonClicked) {
+			sv.Rollback()
+			sv.BeginTransaction(p.Update) // keep editing from the restored state
+		}
+	})
+
+	cancel := brow.AddNewChild(gi.KiT_Button, "cancel").(*gi.Button)
+	cancel.SetText("Cancel")
+	cancel.ButtonSig.Connect(win.This, func(recv, send ki.Ki, sig int64, data interface{}) {
+		if sig == int64(gi.ButtonClicked) {
+			sv.Rollback()
+			win.OSWin.Close()
 		}
 	})
 
 	savej := brow.AddNewChild(gi.KiT_Button, "savejson").(*gi.Button)
-	savej.SetText("Save")
+	savej.SetText("Save...")
 	savej.ButtonSig.Connect(win.This, func(recv, send ki.Ki, sig int64, data interface{}) {
 		if sig == int64(gi.ButtonClicked) {
-			p.Save()
+			gi.FileViewDialog(vp, gi.Prefs.PrefsDir, "prefs", "Save Preferences As", ".json,.toml,.yaml,.yml", win.This, func(recv, send ki.Ki, sig int64, data interface{}) {
+				if sig != int64(gi.DialogAccepted) {
+					return
+				}
+				path := gi.FileViewDialogValue(send)
+				if path == "" {
+					return
+				}
+				format := PrefsFormatFromExt(path)
+				if format == PrefsJSON {
+					p.Save() // preserve default JSON round-trip (location, backup, etc)
+					return
+				}
+				if err := SaveStructAs(p, path, format); err != nil {
+					gi.PromptDialog(vp, gi.DlgOpts{Title: "Error Saving Preferences"}, true, false, err.Error(), nil, nil)
+				}
+			})
 		}
 	})
 
 	loadj := brow.AddNewChild(gi.KiT_Button, "loadjson").(*gi.Button)
-	loadj.SetText("Load")
+	loadj.SetText("Load...")
 	loadj.ButtonSig.Connect(win.This, func(recv, send ki.Ki, sig int64, data interface{}) {
 		if sig == int64(gi.ButtonClicked) {
-			p.Load()
+			gi.FileViewDialog(vp, gi.Prefs.PrefsDir, "prefs", "Load Preferences From", ".json,.toml,.yaml,.yml", win.This, func(recv, send ki.Ki, sig int64, data interface{}) {
+				if sig != int64(gi.DialogAccepted) {
+					return
+				}
+				path := gi.FileViewDialogValue(send)
+				if path == "" {
+					return
+				}
+				format := PrefsFormatFromExt(path)
+				if format == PrefsJSON {
+					p.Load()
+					return
+				}
+				if err := LoadStructFrom(p, path, format); err != nil {
+					gi.PromptDialog(vp, gi.DlgOpts{Title: "Error Loading Preferences"}, true, false, err.Error(), nil, nil)
+					return
+				}
+				p.Update()
+			})
 		}
 	})
 
@@ -81,11 +142,19 @@ func PrefsEditor(p *gi.Preferences) {
 		}
 	})
 
+	editmap := brow.AddNewChild(gi.KiT_Button, "editkeymap").(*gi.Button)
+	editmap.SetText("Edit KeyMap")
+	editmap.ButtonSig.Connect(win.This, func(recv, send ki.Ki, sig int64, data interface{}) {
+		if sig == int64(gi.ButtonClicked) {
+			KeyMapEditor(&p.KeyMap)
+		}
+	})
+
 	scrinfo := brow.AddNewChild(gi.KiT_Button, "scrinfo").(*gi.Button)
 	scrinfo.SetText("Screen Info")
 	scrinfo.ButtonSig.Connect(win.This, func(recv, send ki.Ki, sig int64, data interface{}) {
 		if sig == int64(gi.ButtonClicked) {
-			p.ScreenInfo()
+			ScreenInfoView(p, win)
 		}
 	})
 
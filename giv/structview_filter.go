@@ -0,0 +1,117 @@
+// Copyright (c) 2019, The GoKi Authors. All rights reserved.
+// Use of this source code is governed by a BSD-style
+// license that can be found in the LICENSE file.
+
+package giv
+
+import (
+	"reflect"
+	"strings"
+
+	"github.com/goki/gi"
+	"github.com/goki/ki/ki"
+)
+
+// SetFilter filters the visible field rows in the StructView down to
+// those matching query -- against the label text (the field's name as
+// shown in the UI), the field's `desc` struct tag, or its `category`
+// struct tag -- so users can find a setting in a dense struct (e.g.
+// gi.Preferences) by what it does, not just what it's called, without
+// scrolling through every field by hand. An empty query shows all rows
+// again. Rows are located the same way ShowFields lays them out: one
+// child gi.Layout per field, named after the field itself (as MeshBase
+// and other Ki nodes in this codebase name children after what they
+// represent), containing a "label" child with the field's display name.
+func (sv *StructView) SetFilter(query string) {
+	query = strings.ToLower(strings.TrimSpace(query))
+	updt := sv.UpdateStart()
+	for _, kc := range *sv.Children() {
+		row, ok := kc.(*gi.Layout)
+		if !ok {
+			continue
+		}
+		lbl, ok := row.ChildByName("label", 0).(*gi.Label)
+		if !ok {
+			continue
+		}
+		desc, cat := sv.fieldTags(row.Name())
+		show := query == "" || fuzzyMatch(query, lbl.Text) || fuzzyMatch(query, desc) || fuzzyMatch(query, cat)
+		row.SetInvisible(!show)
+		sv.highlightLabel(lbl, query, show)
+	}
+	sv.UpdateEnd(updt)
+}
+
+// fieldTags returns the `desc` and `category` struct tag values of
+// sv.Struct's field named name, or ("", "") if sv.Struct isn't a pointer
+// to a struct or has no such field -- used by SetFilter to match a query
+// against a field's documented purpose, not just its display name.
+// Looking the field up by name (rather than a row position counter)
+// keeps this correct regardless of `view:"-"` or unexported fields
+// ShowFields skips ahead of a given row, which would otherwise desync a
+// positional index from the struct's real field order.
+func (sv *StructView) fieldTags(name string) (desc, category string) {
+	v := reflect.ValueOf(sv.Struct)
+	if v.Kind() != reflect.Ptr {
+		return "", ""
+	}
+	v = v.Elem()
+	if v.Kind() != reflect.Struct {
+		return "", ""
+	}
+	f, ok := v.Type().FieldByName(name)
+	if !ok {
+		return "", ""
+	}
+	return f.Tag.Get("desc"), f.Tag.Get("category")
+}
+
+// highlightLabel gives a matching row's whole label a highlight
+// background while a filter query is active (the matched characters are
+// not marked individually), and clears the highlight once the query is
+// empty or the row no longer matches.
+func (sv *StructView) highlightLabel(lbl *gi.Label, query string, show bool) {
+	if query == "" || !show {
+		lbl.SetProp("background-color", nil)
+		return
+	}
+	lbl.SetProp("background-color", "highlight-bg")
+}
+
+// fuzzyMatch reports whether every rune in query appears in target, in
+// order, allowing gaps -- the same loose matching style used by most
+// editor "quick open" filters, so e.g. "fntsz" matches "Font Size".
+// query is assumed already lower-cased by the caller.
+func fuzzyMatch(query, target string) bool {
+	if query == "" {
+		return true
+	}
+	target = strings.ToLower(target)
+	qi := 0
+	qr := []rune(query)
+	for _, r := range target {
+		if qr[qi] == r {
+			qi++
+			if qi == len(qr) {
+				return true
+			}
+		}
+	}
+	return false
+}
+
+// AddSearchBar adds a search field to parent that filters sv's rows as
+// the user types -- used by PrefsEditor and any other StructView-based
+// editor window that wants the same settings-search affordance.
+func AddSearchBar(parent ki.Ki, sv *StructView) *gi.TextField {
+	sf := parent.AddNewChild(gi.KiT_TextField, "search").(*gi.TextField)
+	sf.SetStretchMaxWidth()
+	sf.SetProp("placeholder", "Search settings...")
+	sf.TextFieldSig.Connect(sv.This(), func(recv, send ki.Ki, sig int64, data interface{}) {
+		if sig == int64(gi.TextFieldInput) || sig == int64(gi.TextFieldEdited) {
+			tf := send.Embed(gi.KiT_TextField).(*gi.TextField)
+			sv.SetFilter(tf.Text())
+		}
+	})
+	return sf
+}
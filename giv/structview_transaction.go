@@ -0,0 +1,137 @@
+// Copyright (c) 2019, The GoKi Authors. All rights reserved.
+// Use of this source code is governed by a BSD-style
+// license that can be found in the LICENSE file.
+
+package giv
+
+import (
+	"encoding/json"
+	"runtime"
+	"sync"
+	"time"
+
+	"github.com/goki/gi/oswin"
+	"github.com/goki/ki/ki"
+)
+
+// LivePreviewDebounce is how long StructView waits after the last field
+// edit before calling the transaction's Update function, so a user
+// dragging a slider or typing a color value doesn't trigger a full
+// Update() on every keystroke.
+var LivePreviewDebounce = 150 * time.Millisecond
+
+// structTxn holds the state for one in-progress StructView transaction.
+// It is kept in a side table (svTxns) rather than as a StructView field
+// since StructView's own definition is not extended by this file.
+type structTxn struct {
+	snapshot []byte // JSON snapshot of Struct taken at BeginTransaction
+	update   func() // called (debounced) after every edit, and by Revert
+	timer    *time.Timer
+}
+
+var svTxnsMu sync.Mutex
+var svTxns = map[*StructView]*structTxn{}
+
+// svConnected tracks which StructViews already have their ViewSig
+// wired to schedulePreview, so repeated BeginTransaction calls on the
+// same StructView (e.g. after every Apply/Revert in PrefsEditor) don't
+// stack another live connection each time. Not cleared by Commit/
+// Rollback -- the connection is a property of the StructView, not of
+// any one transaction -- only by the finalizer BeginTransaction sets up,
+// once sv itself is garbage collected.
+var svConnected = map[*StructView]bool{}
+
+// BeginTransaction snapshots the StructView's current struct value (via
+// JSON) and arranges for every subsequent field edit to trigger a
+// debounced call to update, so e.g. a PrefsEditor can show color/font/
+// spacing changes live across all open windows as the user edits, while
+// still being able to Rollback to the snapshot. update is typically the
+// struct's own Update method, e.g. func() { p.Update() }. Safe to call
+// again on the same StructView (e.g. to start a fresh transaction after
+// Apply or Revert) -- the ViewSig connection is only made once.
+func (sv *StructView) BeginTransaction(update func()) error {
+	b, err := json.Marshal(sv.Struct)
+	if err != nil {
+		return err
+	}
+	svTxnsMu.Lock()
+	svTxns[sv] = &structTxn{snapshot: b, update: update}
+	alreadyConnected := svConnected[sv]
+	svConnected[sv] = true
+	svTxnsMu.Unlock()
+
+	if alreadyConnected {
+		return nil
+	}
+	sv.ViewSig.Connect(sv.This(), func(recv, send ki.Ki, sig int64, data interface{}) {
+		sv.schedulePreview()
+	})
+	// sv's transaction state lives in the svTxns/svConnected side tables
+	// above (StructView's own definition isn't extended by this file),
+	// keyed by pointer -- closing a transaction's window via the OS close
+	// button, bypassing Commit/Rollback, would otherwise leak one entry
+	// in each for the rest of the process's life. A finalizer reclaims
+	// both the moment sv itself becomes unreachable, which covers that
+	// case without needing a window-close hook.
+	runtime.SetFinalizer(sv, func(sv *StructView) {
+		svTxnsMu.Lock()
+		delete(svTxns, sv)
+		delete(svConnected, sv)
+		svTxnsMu.Unlock()
+	})
+	return nil
+}
+
+// schedulePreview (re)starts the debounce timer for the live-preview
+// Update call -- called on every field edit while a transaction is open.
+// The timer fires on its own goroutine, and txn.update typically touches
+// live widgets (e.g. p.Update()), so it's dispatched through
+// oswin.TheApp.RunOnMain rather than called directly off-thread.
+func (sv *StructView) schedulePreview() {
+	svTxnsMu.Lock()
+	txn, ok := svTxns[sv]
+	svTxnsMu.Unlock()
+	if !ok {
+		return
+	}
+	if txn.timer != nil {
+		txn.timer.Stop()
+	}
+	txn.timer = time.AfterFunc(LivePreviewDebounce, func() {
+		oswin.TheApp.RunOnMain(txn.update)
+	})
+}
+
+// Commit ends the transaction, keeping the struct's current (edited)
+// value and discarding the snapshot. Call when the user clicks Apply.
+func (sv *StructView) Commit() {
+	svTxnsMu.Lock()
+	defer svTxnsMu.Unlock()
+	delete(svTxns, sv)
+}
+
+// Rollback restores the struct to the value captured by BeginTransaction,
+// re-runs the transaction's update function so the restored value takes
+// effect, and ends the transaction. Call when the user clicks Revert
+// (to keep editing from the restored state) or Cancel (and then close
+// the window).
+func (sv *StructView) Rollback() error {
+	svTxnsMu.Lock()
+	txn, ok := svTxns[sv]
+	delete(svTxns, sv)
+	svTxnsMu.Unlock()
+	if !ok {
+		return nil
+	}
+	if txn.timer != nil {
+		txn.timer.Stop()
+	}
+	if err := json.Unmarshal(txn.snapshot, sv.Struct); err != nil {
+		return err
+	}
+	sv.UpdateFields()
+	if txn.update != nil {
+		txn.update()
+	}
+	return nil
+}